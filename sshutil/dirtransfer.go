@@ -0,0 +1,275 @@
+package sshutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+)
+
+// TransferKind 区分一次目录传输中单个条目的方向
+type TransferKind int
+
+const (
+	KindUpload   TransferKind = iota // 从本地上传到远程
+	KindDownload                     // 从远程下载到本地
+)
+
+// ProgressFunc 在传输过程中被回调，用于报告path当前已传输bytesTransferred字节，总大小为totalBytes
+type ProgressFunc func(path string, bytesTransferred, totalBytes int64)
+
+// TransferInfo 记录目录传输中单个文件的结果
+type TransferInfo struct {
+	Kind         TransferKind
+	Local        string
+	Dst          string
+	TransferByte int64
+}
+
+// TransferSummary 汇总一次UploadDir/DownloadDir的结果
+type TransferSummary struct {
+	Files []TransferInfo
+}
+
+// dirTransferConfig 聚合UploadDir/DownloadDir支持的可选配置，由TransferOption修改
+type dirTransferConfig struct {
+	workers  int
+	progress ProgressFunc
+}
+
+// TransferOption 用于修改UploadDir/DownloadDir的行为
+type TransferOption func(*dirTransferConfig)
+
+// WithWorkers 设置并发传输文件的worker数量，默认runtime.NumCPU()
+func WithWorkers(n int) TransferOption {
+	return func(c *dirTransferConfig) {
+		if n > 0 {
+			c.workers = n
+		}
+	}
+}
+
+// WithProgress 设置每个文件传输过程中的进度回调
+func WithProgress(fn ProgressFunc) TransferOption {
+	return func(c *dirTransferConfig) { c.progress = fn }
+}
+
+// dirTransferItem 是walker产出、worker消费的传输单元
+type dirTransferItem struct {
+	localPath  string
+	remotePath string
+	info       os.FileInfo
+}
+
+// UploadDir 递归地将本地目录localPath上传到远程主机，遵循rsync的尾部斜杠规则决定是把
+// localPath本身作为remotePath的子目录，还是只把其内容铺到remotePath下。
+// 目录遍历与文件传输通过一个有缓冲的channel解耦：单个walker产出传输条目，
+// 多个worker并发消费并上传，worker数量由WithWorkers控制，默认runtime.NumCPU()。
+// 任意worker出错都会取消其余传输，所有错误通过errors.Join合并返回。
+func (c *SSHClient) UploadDir(localPath, remotePath string, opts ...TransferOption) (*TransferSummary, error) {
+	root := resolveUploadRoot(localPath, remotePath)
+
+	var sftpClient *sftp.Client
+	err := c.withSFTP(func(client *sftp.Client) error {
+		if err := client.MkdirAll(root); err != nil {
+			return err
+		}
+		sftpClient = client
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newDirTransferConfig(opts)
+	items := make(chan dirTransferItem, cfg.workers*2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var walkErr error
+	go func() {
+		defer close(items)
+		walkErr = filepath.Walk(localPath, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			relPath, err := filepath.Rel(localPath, p)
+			if err != nil {
+				return err
+			}
+			if relPath == "." {
+				return nil
+			}
+			dstPath := path.Join(root, filepath.ToSlash(relPath))
+
+			if info.IsDir() {
+				return sftpClient.MkdirAll(dstPath)
+			}
+
+			select {
+			case items <- dirTransferItem{localPath: p, remotePath: dstPath, info: info}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	results, err := runDirTransferWorkers(ctx, cancel, cfg, items, func(item dirTransferItem) (TransferInfo, error) {
+		n, err := uploadFile(sftpClient, item.localPath, item.remotePath, item.info, cfg.progress)
+		return TransferInfo{Kind: KindUpload, Local: item.localPath, Dst: item.remotePath, TransferByte: n}, err
+	})
+
+	if walkErr != nil {
+		err = errors.Join(err, walkErr)
+	}
+	return &TransferSummary{Files: results}, err
+}
+
+// DownloadDir 递归地将远程主机上的目录remotePath下载到本地，尾部斜杠规则与UploadDir对称，
+// 并发模型与错误聚合方式也与UploadDir一致。
+func (c *SSHClient) DownloadDir(remotePath, localPath string, opts ...TransferOption) (*TransferSummary, error) {
+	root := resolveDownloadRoot(remotePath, localPath)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+
+	remoteRoot := path.Clean(filepath.ToSlash(remotePath))
+
+	var sftpClient *sftp.Client
+	err := c.withSFTP(func(client *sftp.Client) error {
+		if _, err := client.Stat(remoteRoot); err != nil {
+			return err
+		}
+		sftpClient = client
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := newDirTransferConfig(opts)
+	items := make(chan dirTransferItem, cfg.workers*2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var walkErr error
+	go func() {
+		defer close(items)
+		walker := sftpClient.Walk(remoteRoot)
+		for walker.Step() {
+			if err := walker.Err(); err != nil {
+				walkErr = err
+				return
+			}
+
+			info := walker.Stat()
+			relPath := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), remoteRoot), "/")
+			if relPath == "" {
+				continue
+			}
+			dstPath := filepath.Join(root, filepath.FromSlash(relPath))
+
+			if info.IsDir() {
+				if err := os.MkdirAll(dstPath, 0o755); err != nil {
+					walkErr = err
+					return
+				}
+				continue
+			}
+
+			select {
+			case items <- dirTransferItem{localPath: dstPath, remotePath: walker.Path(), info: info}:
+			case <-ctx.Done():
+				walkErr = ctx.Err()
+				return
+			}
+		}
+	}()
+
+	results, err := runDirTransferWorkers(ctx, cancel, cfg, items, func(item dirTransferItem) (TransferInfo, error) {
+		n, err := downloadFile(sftpClient, item.remotePath, item.localPath, item.info, cfg.progress)
+		return TransferInfo{Kind: KindDownload, Local: item.localPath, Dst: item.remotePath, TransferByte: n}, err
+	})
+
+	if walkErr != nil {
+		err = errors.Join(err, walkErr)
+	}
+	return &TransferSummary{Files: results}, err
+}
+
+func newDirTransferConfig(opts []TransferOption) *dirTransferConfig {
+	cfg := &dirTransferConfig{workers: runtime.NumCPU()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// runDirTransferWorkers 启动cfg.workers个worker并发消费items，用transfer传输每个条目，
+// 并把结果和错误分别收集起来；任意worker出错都会cancel，使其余worker和walker尽快停止。
+func runDirTransferWorkers(ctx context.Context, cancel context.CancelFunc, cfg *dirTransferConfig, items <-chan dirTransferItem, transfer func(dirTransferItem) (TransferInfo, error)) ([]TransferInfo, error) {
+	var (
+		mu      sync.Mutex
+		results []TransferInfo
+		errs    []error
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < cfg.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				info, err := transfer(item)
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+					cancel()
+				} else {
+					results = append(results, info)
+				}
+				mu.Unlock()
+
+				if ctx.Err() != nil {
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return results, errors.Join(errs...)
+}
+
+// trackWriter 返回一个io.Writer，每次写入都会把累计进度回报给progress（progress为nil时原样返回dst）
+func trackWriter(path string, dst io.Writer, total int64, progress ProgressFunc) io.Writer {
+	if progress == nil {
+		return dst
+	}
+	return &progressWriter{path: path, dst: dst, total: total, progress: progress}
+}
+
+type progressWriter struct {
+	path     string
+	dst      io.Writer
+	total    int64
+	written  int64
+	progress ProgressFunc
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.dst.Write(p)
+	w.written += int64(n)
+	w.progress(w.path, w.written, w.total)
+	return n, err
+}
@@ -0,0 +1,102 @@
+package sshutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func fillItems(n int) chan dirTransferItem {
+	items := make(chan dirTransferItem, n)
+	for i := 0; i < n; i++ {
+		items <- dirTransferItem{localPath: fmt.Sprintf("file%d", i)}
+	}
+	close(items)
+	return items
+}
+
+func TestRunDirTransferWorkersAllSucceed(t *testing.T) {
+	const n = 20
+	items := fillItems(n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := &dirTransferConfig{workers: 4}
+
+	var processed int32
+	results, err := runDirTransferWorkers(ctx, cancel, cfg, items, func(item dirTransferItem) (TransferInfo, error) {
+		atomic.AddInt32(&processed, 1)
+		return TransferInfo{Local: item.localPath, TransferByte: 1}, nil
+	})
+
+	if err != nil {
+		t.Fatalf("runDirTransferWorkers() error = %v, want nil", err)
+	}
+	if len(results) != n {
+		t.Fatalf("got %d results, want %d", len(results), n)
+	}
+	if int(processed) != n {
+		t.Fatalf("processed %d items, want %d", processed, n)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("ctx was cancelled even though no worker failed: %v", ctx.Err())
+	}
+}
+
+func TestRunDirTransferWorkersCancelsOnError(t *testing.T) {
+	const n = 50
+	items := fillItems(n)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := &dirTransferConfig{workers: 4}
+
+	boom := errors.New("boom")
+	var processed int32
+	_, err := runDirTransferWorkers(ctx, cancel, cfg, items, func(item dirTransferItem) (TransferInfo, error) {
+		if atomic.AddInt32(&processed, 1) == 1 {
+			return TransferInfo{}, boom
+		}
+		return TransferInfo{Local: item.localPath}, nil
+	})
+
+	if !errors.Is(err, boom) {
+		t.Fatalf("runDirTransferWorkers() error = %v, want it to wrap %v", err, boom)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("expected ctx to be cancelled after a worker error")
+	}
+	if int(processed) >= n {
+		t.Fatalf("expected cancellation to stop workers well before all %d items were processed, processed %d", n, processed)
+	}
+}
+
+func TestRunDirTransferWorkersJoinsMultipleErrors(t *testing.T) {
+	errA := errors.New("err a")
+	errB := errors.New("err b")
+
+	items := make(chan dirTransferItem, 2)
+	items <- dirTransferItem{localPath: "a"}
+	items <- dirTransferItem{localPath: "b"}
+	close(items)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := &dirTransferConfig{workers: 2}
+
+	_, err := runDirTransferWorkers(ctx, cancel, cfg, items, func(item dirTransferItem) (TransferInfo, error) {
+		if item.localPath == "a" {
+			return TransferInfo{}, errA
+		}
+		return TransferInfo{}, errB
+	})
+
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("runDirTransferWorkers() error = %v, want it to join both errA and errB", err)
+	}
+}
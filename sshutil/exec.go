@@ -0,0 +1,183 @@
+package sshutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ExecResult 表示一次远程命令执行的结果
+type ExecResult struct {
+	Cmd      string // 实际执行的命令
+	Stdout   []byte // 标准输出内容
+	Stderr   []byte // 标准错误内容
+	ExitCode int    // 命令的退出状态码
+}
+
+// execConfig 聚合Run/Start支持的可选配置，由RunOption修改
+type execConfig struct {
+	stdout io.Writer
+	stderr io.Writer
+	stdin  io.Reader
+	env    map[string]string
+	pty    *ptyRequest
+}
+
+// ptyRequest 描述WithPTY请求的伪终端参数
+type ptyRequest struct {
+	term string
+	h, w int
+}
+
+// RunOption 用于修改Run/Start的行为
+type RunOption func(*execConfig)
+
+// WithStdoutWriter 在写入内部缓冲区的同时，将标准输出实时转发给w
+func WithStdoutWriter(w io.Writer) RunOption {
+	return func(c *execConfig) { c.stdout = w }
+}
+
+// WithStderrWriter 在写入内部缓冲区的同时，将标准错误实时转发给w
+func WithStderrWriter(w io.Writer) RunOption {
+	return func(c *execConfig) { c.stderr = w }
+}
+
+// WithStdin 将r作为远程命令的标准输入
+func WithStdin(r io.Reader) RunOption {
+	return func(c *execConfig) { c.stdin = r }
+}
+
+// WithEnv 在会话上设置环境变量（通过session.Setenv，是否生效取决于服务端的AcceptEnv配置）
+func WithEnv(env map[string]string) RunOption {
+	return func(c *execConfig) { c.env = env }
+}
+
+// WithPTY 为命令申请一个term类型、h行w列的伪终端
+func WithPTY(term string, h, w int) RunOption {
+	return func(c *execConfig) { c.pty = &ptyRequest{term: term, h: h, w: w} }
+}
+
+// Handle 表示一次通过Start发起的命令执行，配合Wait获取最终结果
+type Handle struct {
+	ctx        context.Context
+	session    *ssh.Session
+	cmd        string
+	stdoutBuf  *bytes.Buffer
+	stderrBuf  *bytes.Buffer
+	done       chan error
+	cancelOnce func()
+}
+
+// Run 在远程执行一次命令并等待其结束，ctx被取消时会向会话发送SIGKILL并关闭会话。
+func (c *SSHClient) Run(ctx context.Context, cmd string, opts ...RunOption) (*ExecResult, error) {
+	h, err := c.Start(ctx, cmd, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Wait()
+}
+
+// Start 在远程发起一次命令执行但不等待其结束，返回的Handle可用Wait获取最终结果。
+// ctx被取消时，Start启动的后台goroutine会向会话发送SIGKILL并关闭会话，随后Wait返回ctx.Err()。
+func (c *SSHClient) Start(ctx context.Context, cmd string, opts ...RunOption) (*Handle, error) {
+	cfg := &execConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range cfg.env {
+		if err := session.Setenv(name, value); err != nil {
+			session.Close()
+			return nil, err
+		}
+	}
+
+	if cfg.pty != nil {
+		if err := session.RequestPty(cfg.pty.term, cfg.pty.h, cfg.pty.w, ssh.TerminalModes{}); err != nil {
+			session.Close()
+			return nil, err
+		}
+	}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	session.Stdout = teeWriter(&stdoutBuf, cfg.stdout)
+	session.Stderr = teeWriter(&stderrBuf, cfg.stderr)
+	if cfg.stdin != nil {
+		session.Stdin = cfg.stdin
+	}
+
+	if err := session.Start(cmd); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	h := &Handle{
+		ctx:       ctx,
+		session:   session,
+		cmd:       cmd,
+		stdoutBuf: &stdoutBuf,
+		stderrBuf: &stderrBuf,
+		done:      make(chan error, 1),
+	}
+
+	go func() { h.done <- session.Wait() }()
+
+	if ctx != nil {
+		stop := make(chan struct{})
+		h.cancelOnce = func() { close(stop) }
+		go func() {
+			select {
+			case <-ctx.Done():
+				session.Signal(ssh.SIGKILL)
+				session.Close()
+			case <-stop:
+			}
+		}()
+	}
+
+	return h, nil
+}
+
+// Wait 阻塞直到命令结束，返回汇总了退出码与输出的ExecResult
+func (h *Handle) Wait() (*ExecResult, error) {
+	err := <-h.done
+	if h.cancelOnce != nil {
+		h.cancelOnce()
+	}
+
+	result := &ExecResult{
+		Cmd:    h.cmd,
+		Stdout: h.stdoutBuf.Bytes(),
+		Stderr: h.stderrBuf.Bytes(),
+	}
+
+	if h.ctx != nil && h.ctx.Err() != nil {
+		return result, h.ctx.Err()
+	}
+
+	if err != nil {
+		if exitErr, ok := err.(*ssh.ExitError); ok {
+			result.ExitCode = exitErr.ExitStatus()
+			return result, nil
+		}
+		return result, err
+	}
+
+	return result, nil
+}
+
+// teeWriter 返回一个同时写入buf与extra的io.Writer，extra为nil时只写入buf
+func teeWriter(buf *bytes.Buffer, extra io.Writer) io.Writer {
+	if extra == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, extra)
+}
@@ -0,0 +1,225 @@
+package sshutil
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// LocalForward 在本地localAddr上监听，把每一个连接通过SSH隧道转发到远程主机能访问到的remoteAddr
+// （即ssh -L）。当ctx被取消时停止监听，已建立的连接不会随之关闭，会一直转发到任一端关闭为止；
+// 返回拨号对端失败与监听出错的汇总，转发过程中的读写错误不计入其中。
+func (c *SSHClient) LocalForward(ctx context.Context, localAddr, remoteAddr string) error {
+	listener, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		return err
+	}
+
+	return c.serveForward(ctx, listener, func() (net.Conn, error) {
+		return c.client.Dial("tcp", remoteAddr)
+	})
+}
+
+// RemoteForward 在远程主机上监听remoteAddr，把每一个连接转发到本地能访问到的localAddr
+// （即ssh -R）。当ctx被取消时停止监听；返回拨号对端失败与监听出错的汇总，转发过程中的读写错误不计入其中。
+func (c *SSHClient) RemoteForward(ctx context.Context, remoteAddr, localAddr string) error {
+	listener, err := c.client.Listen("tcp", remoteAddr)
+	if err != nil {
+		return err
+	}
+
+	return c.serveForward(ctx, listener, func() (net.Conn, error) {
+		return net.Dial("tcp", localAddr)
+	})
+}
+
+// serveForward 是LocalForward/RemoteForward共用的accept循环：对listener上的每个连接，
+// 调用dialPeer建立对端连接并双向转发，ctx取消时关闭listener以结束循环。
+func (c *SSHClient) serveForward(ctx context.Context, listener net.Listener, dialPeer func() (net.Conn, error)) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var errs []error
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return errors.Join(errs...)
+			}
+			errs = append(errs, err)
+			return errors.Join(errs...)
+		}
+
+		peer, err := dialPeer()
+		if err != nil {
+			conn.Close()
+			errs = append(errs, err)
+			continue
+		}
+
+		go proxyConn(conn, peer)
+	}
+}
+
+// proxyConn在a和b之间双向转发数据，直到任意一方关闭或出错，随后关闭两端连接。
+func proxyConn(a, b net.Conn) {
+	defer a.Close()
+	defer b.Close()
+
+	done := make(chan struct{}, 2)
+	copyFn := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+
+	go copyFn(a, b)
+	go copyFn(b, a)
+	<-done
+}
+
+const (
+	socks5Version    = 0x05
+	socks5CmdConnect = 0x01
+	socks5AtypIPv4   = 0x01
+	socks5AtypDomain = 0x03
+	socks5AtypIPv6   = 0x04
+)
+
+// DynamicForward 在本地localAddr上启动一个极简的SOCKS5服务（不支持认证），把每一个CONNECT
+// 请求的目标地址通过SSH隧道拨号转发（即ssh -D）。当ctx被取消时停止监听。
+func (c *SSHClient) DynamicForward(ctx context.Context, localAddr string) error {
+	listener, err := net.Listen("tcp", localAddr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var errs []error
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return errors.Join(errs...)
+			}
+			errs = append(errs, err)
+			return errors.Join(errs...)
+		}
+
+		go c.handleSocks5(conn)
+	}
+}
+
+// handleSocks5处理单个SOCKS5客户端连接：完成无认证握手，解析CONNECT请求，
+// 通过SSH连接拨号目标地址，然后在两端之间双向转发数据。
+func (c *SSHClient) handleSocks5(conn net.Conn) {
+	defer conn.Close()
+
+	if err := socks5Handshake(conn); err != nil {
+		return
+	}
+
+	target, err := socks5ReadRequest(conn)
+	if err != nil {
+		socks5Reply(conn, 0x01)
+		return
+	}
+
+	peer, err := c.client.Dial("tcp", target)
+	if err != nil {
+		socks5Reply(conn, 0x05)
+		return
+	}
+
+	if err := socks5Reply(conn, 0x00); err != nil {
+		peer.Close()
+		return
+	}
+
+	proxyConn(conn, peer)
+}
+
+// socks5Handshake读取客户端提供的认证方式列表，并回复使用"无需认证"(0x00)
+func socks5Handshake(conn net.Conn) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return fmt.Errorf("sshutil: unsupported SOCKS version %d", header[0])
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return err
+	}
+
+	_, err := conn.Write([]byte{socks5Version, 0x00})
+	return err
+}
+
+// socks5ReadRequest解析SOCKS5的CONNECT请求，返回"host:port"形式的目标地址
+func socks5ReadRequest(conn net.Conn) (string, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socks5Version {
+		return "", fmt.Errorf("sshutil: unsupported SOCKS version %d", header[0])
+	}
+	if header[1] != socks5CmdConnect {
+		return "", fmt.Errorf("sshutil: unsupported SOCKS command %d", header[1])
+	}
+
+	var host string
+	switch header[3] {
+	case socks5AtypIPv4:
+		addr := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return "", err
+		}
+		host = net.IP(addr).String()
+	case socks5AtypIPv6:
+		addr := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return "", err
+		}
+		host = net.IP(addr).String()
+	case socks5AtypDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("sshutil: unsupported SOCKS address type %d", header[3])
+	}
+
+	var port uint16
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(conn, portBytes); err != nil {
+		return "", err
+	}
+	port = binary.BigEndian.Uint16(portBytes)
+
+	return net.JoinHostPort(host, fmt.Sprintf("%d", port)), nil
+}
+
+// socks5Reply向客户端回复CONNECT请求的处理结果，replyCode为0x00表示成功
+func socks5Reply(conn net.Conn, replyCode byte) error {
+	reply := []byte{socks5Version, replyCode, 0x00, socks5AtypIPv4, 0, 0, 0, 0, 0, 0}
+	_, err := conn.Write(reply)
+	return err
+}
@@ -0,0 +1,102 @@
+package sshutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// ClientOptions 描述建立SSH连接所需的全部配置，配合NewSSHClientWithOptions使用。
+// Auth和HostKeyCallback一般通过WithPassword、WithPrivateKey、WithKnownHosts等辅助函数构造。
+type ClientOptions struct {
+	Host string // 远程主机地址
+	Port int    // 远程主机端口
+	User string // 连接用户名，为空时使用当前系统用户
+
+	Auth            []ssh.AuthMethod    // 认证方式列表，为空时默认使用~/.ssh/id_rsa私钥
+	HostKeyCallback ssh.HostKeyCallback // 主机公钥校验回调，为空时退化为InsecureIgnoreHostKey
+	Timeout         time.Duration       // 连接超时时间，为0时默认5秒
+}
+
+// WithPassword 构造一个密码认证方式
+func WithPassword(password string) ssh.AuthMethod {
+	return ssh.Password(password)
+}
+
+// WithPrivateKey 从path指定的私钥文件构造一个公钥认证方式，passphrase为空表示私钥未加密
+func WithPrivateKey(path string, passphrase string) (ssh.AuthMethod, error) {
+	keyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithPrivateKeyBytes(keyBytes, passphrase)
+}
+
+// WithPrivateKeyBytes 使用内存中的私钥内容构造一个公钥认证方式，passphrase为空表示私钥未加密
+func WithPrivateKeyBytes(keyBytes []byte, passphrase string) (ssh.AuthMethod, error) {
+	var (
+		signer ssh.Signer
+		err    error
+	)
+
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(keyBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ssh.PublicKeys(signer), nil
+}
+
+// WithAgent 通过SSH_AUTH_SOCK连接本机的ssh-agent，构造一个委托给agent签名的公钥认证方式
+func WithAgent() (ssh.AuthMethod, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("sshutil: SSH_AUTH_SOCK is not set")
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, err
+	}
+
+	agentClient := agent.NewClient(conn)
+	return ssh.PublicKeysCallback(agentClient.Signers), nil
+}
+
+// WithKeyboardInteractive 构造一个keyboard-interactive认证方式，对服务端发起的每一个提示都回答password
+func WithKeyboardInteractive(password string) ssh.AuthMethod {
+	return ssh.KeyboardInteractive(func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i := range answers {
+			answers[i] = password
+		}
+		return answers, nil
+	})
+}
+
+// WithKnownHosts 根据path指定的known_hosts文件构造主机公钥校验回调
+func WithKnownHosts(path string) (ssh.HostKeyCallback, error) {
+	return knownhosts.New(path)
+}
+
+// defaultPrivateKeyPath 返回未显式指定认证方式时使用的默认私钥路径 ~/.ssh/id_rsa
+func defaultPrivateKeyPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".ssh", "id_rsa"), nil
+}
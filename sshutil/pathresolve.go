@@ -0,0 +1,52 @@
+package sshutil
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hasTrailingSlash 判断路径在被Clean之前是否以路径分隔符结尾，
+// 这是rsync用来区分"拷贝目录本身"还是"只拷贝目录内容"的信号。
+func hasTrailingSlash(p string) bool {
+	return strings.HasSuffix(p, "/") || strings.HasSuffix(p, "\\")
+}
+
+// resolveUploadRoot 按照rsync的尾部斜杠规则，计算本地目录localPath上传到远程remotePath时
+// 实际应当写入的远程根目录：localPath带尾部斜杠（如"a/"）时只拷贝其内容到remotePath下，
+// 不带尾部斜杠（如"a"）时把目录本身拷贝为remotePath下的子目录。
+func resolveUploadRoot(localPath, remotePath string) string {
+	remoteRoot := path.Clean(filepath.ToSlash(remotePath))
+	if hasTrailingSlash(localPath) {
+		return remoteRoot
+	}
+	return path.Join(remoteRoot, filepath.Base(filepath.Clean(localPath)))
+}
+
+// resolveDownloadRoot 按照rsync的尾部斜杠规则，计算远程目录remotePath下载到本地localPath时
+// 实际应当写入的本地根目录，规则与resolveUploadRoot对称。
+func resolveDownloadRoot(remotePath, localPath string) string {
+	localRoot := filepath.Clean(localPath)
+	if hasTrailingSlash(remotePath) {
+		return localRoot
+	}
+	return filepath.Join(localRoot, path.Base(path.Clean(filepath.ToSlash(remotePath))))
+}
+
+// resolveFileDest 计算单个文件拷贝的目标路径：dst以路径分隔符结尾时把它当成目录，
+// 在其后追加src的文件名；否则dst本身就是目标文件名（可用于改名）。
+// isRemote为true时按远程的POSIX风格拼接路径，否则按本地平台风格拼接。
+func resolveFileDest(srcName, dst string, isRemote bool) string {
+	if isRemote {
+		dst = filepath.ToSlash(dst)
+		if !hasTrailingSlash(dst) {
+			return path.Clean(dst)
+		}
+		return path.Join(path.Clean(dst), srcName)
+	}
+
+	if !hasTrailingSlash(dst) {
+		return filepath.Clean(dst)
+	}
+	return filepath.Join(filepath.Clean(dst), srcName)
+}
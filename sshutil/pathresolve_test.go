@@ -0,0 +1,69 @@
+package sshutil
+
+import "testing"
+
+func TestResolveUploadRoot(t *testing.T) {
+	cases := []struct {
+		name       string
+		localPath  string
+		remotePath string
+		want       string
+	}{
+		{"dir with trailing slash copies contents", "a/b/", "/dst", "/dst"},
+		{"dir without trailing slash copies directory itself", "a/b", "/dst", "/dst/b"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resolveUploadRoot(tc.localPath, tc.remotePath)
+			if got != tc.want {
+				t.Errorf("resolveUploadRoot(%q, %q) = %q, want %q", tc.localPath, tc.remotePath, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveDownloadRoot(t *testing.T) {
+	cases := []struct {
+		name       string
+		remotePath string
+		localPath  string
+		want       string
+	}{
+		{"dir with trailing slash copies contents", "/a/b/", "dst", "dst"},
+		{"dir without trailing slash copies directory itself", "/a/b", "dst", "dst/b"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resolveDownloadRoot(tc.remotePath, tc.localPath)
+			if got != tc.want {
+				t.Errorf("resolveDownloadRoot(%q, %q) = %q, want %q", tc.remotePath, tc.localPath, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveFileDest(t *testing.T) {
+	cases := []struct {
+		name     string
+		srcName  string
+		dst      string
+		isRemote bool
+		want     string
+	}{
+		{"file to dir appends filename", "file.txt", "/dst/", true, "/dst/file.txt"},
+		{"file to file renames", "file.txt", "/dst/renamed.txt", true, "/dst/renamed.txt"},
+		{"local file to dir appends filename", "file.txt", "dst/", false, "dst/file.txt"},
+		{"local file to file renames", "file.txt", "dst/renamed.txt", false, "dst/renamed.txt"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resolveFileDest(tc.srcName, tc.dst, tc.isRemote)
+			if got != tc.want {
+				t.Errorf("resolveFileDest(%q, %q, %v) = %q, want %q", tc.srcName, tc.dst, tc.isRemote, got, tc.want)
+			}
+		})
+	}
+}
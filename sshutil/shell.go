@@ -0,0 +1,144 @@
+package sshutil
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ShellOptions描述打开交互式Shell所需的伪终端参数
+type ShellOptions struct {
+	Term   string            // 终端类型，为空时默认"xterm"
+	Height int               // 终端行数
+	Width  int               // 终端列数
+	Modes  ssh.TerminalModes // 终端模式标志，为空时使用一组保守的默认值
+}
+
+// Shell表示一个打开了PTY的交互式Shell会话，实现io.Reader/io.Writer：
+// Read读取远程Shell的标准输出，Write写入远程Shell的标准输入；标准错误可通过Stderr单独读取。
+type Shell struct {
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+	stderr  io.Reader
+
+	done       chan error
+	cancelOnce func()
+}
+
+// Shell打开一个带PTY的交互式会话并启动远程Shell，可配合Shell返回值进行读写、窗口大小调整
+// 和信号发送，适合在此基础上构建终端复用器或expect风格的驱动程序。
+// ctx被取消时会向会话发送SIGKILL并关闭会话。
+func (c *SSHClient) Shell(ctx context.Context, opts ShellOptions) (*Shell, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	term := opts.Term
+	if term == "" {
+		term = "xterm"
+	}
+
+	modes := opts.Modes
+	if modes == nil {
+		modes = ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+	}
+
+	if err := session.RequestPty(term, opts.Height, opts.Width, modes); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	if err := session.Shell(); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	sh := &Shell{
+		session: session,
+		stdin:   stdin,
+		stdout:  stdout,
+		stderr:  stderr,
+		done:    make(chan error, 1),
+	}
+
+	go func() { sh.done <- session.Wait() }()
+
+	if ctx != nil {
+		stop := make(chan struct{})
+		sh.cancelOnce = func() { close(stop) }
+		go func() {
+			select {
+			case <-ctx.Done():
+				session.Signal(ssh.SIGKILL)
+				session.Close()
+			case <-stop:
+			}
+		}()
+	}
+
+	return sh, nil
+}
+
+// Read从远程Shell的标准输出读取数据
+func (s *Shell) Read(p []byte) (int, error) {
+	return s.stdout.Read(p)
+}
+
+// Write向远程Shell的标准输入写入数据
+func (s *Shell) Write(p []byte) (int, error) {
+	return s.stdin.Write(p)
+}
+
+// Stderr返回远程Shell的标准错误流
+func (s *Shell) Stderr() io.Reader {
+	return s.stderr
+}
+
+// Resize通知远程PTY调整为h行w列
+func (s *Shell) Resize(h, w int) error {
+	return s.session.WindowChange(h, w)
+}
+
+// SendSignal向远程Shell所在的会话发送信号
+func (s *Shell) SendSignal(sig ssh.Signal) error {
+	return s.session.Signal(sig)
+}
+
+// Wait阻塞直到远程Shell退出
+func (s *Shell) Wait() error {
+	err := <-s.done
+	if s.cancelOnce != nil {
+		s.cancelOnce()
+	}
+	return err
+}
+
+// Close关闭Shell会话
+func (s *Shell) Close() error {
+	return s.session.Close()
+}
@@ -1,39 +1,84 @@
 package sshutil
 
 import (
-	"fmt"
+	"errors"
 	"io"
-	"io/ioutil"
-	"log"
 	"net"
 	"os"
-	"path/filepath"
+	"os/user"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/pkg/sftp"
 	"golang.org/x/crypto/ssh"
 )
 
 // SSHClient 表示一个SSH客户端连接
 type SSHClient struct {
-	client *ssh.Client   // SSH连接客户端
+	client *ssh.Client       // SSH连接客户端
 	config *ssh.ClientConfig // SSH连接客户端配置
+
+	sftpMu     sync.Mutex   // 保护sftpClient的并发访问
+	sftpClient *sftp.Client // 延迟创建的SFTP客户端，连接断开后会被重新打开
 }
 
-// NewSSHClient 创建一个新的SSHClient对象
+// NewSSHClient 创建一个新的SSHClient对象，使用密码认证。
+// 保留该函数是为了向后兼容，新代码推荐使用NewSSHClientWithOptions。
 func NewSSHClient(host string, port int, user string, password string) (*SSHClient, error) {
+	return NewSSHClientWithOptions(ClientOptions{
+		Host: host,
+		Port: port,
+		User: user,
+		Auth: []ssh.AuthMethod{WithPassword(password)},
+	})
+}
+
+// NewSSHClientWithOptions 通过ClientOptions创建一个新的SSHClient。
+// 未指定User时使用当前系统用户，未指定Auth时默认使用~/.ssh/id_rsa私钥，
+// 未指定HostKeyCallback时退化为忽略主机公钥校验，未指定Timeout时默认5秒。
+func NewSSHClientWithOptions(opts ClientOptions) (*SSHClient, error) {
+	username := opts.User
+	if username == "" {
+		currentUser, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+		username = currentUser.Username
+	}
+
+	auth := opts.Auth
+	if len(auth) == 0 {
+		keyPath, err := defaultPrivateKeyPath()
+		if err != nil {
+			return nil, err
+		}
+		defaultAuth, err := WithPrivateKey(keyPath, "")
+		if err != nil {
+			return nil, err
+		}
+		auth = []ssh.AuthMethod{defaultAuth}
+	}
+
+	hostKeyCallback := opts.HostKeyCallback
+	if hostKeyCallback == nil {
+		hostKeyCallback = ssh.InsecureIgnoreHostKey() // 忽略主机公钥验证
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
 	sshConfig := &ssh.ClientConfig{
-		User: user, // 连接用户名
-		Auth: []ssh.AuthMethod{
-			ssh.Password(password), // 连接密码
-		},
-		Timeout:         5 * time.Second, // 连接超时时间
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 忽略主机公钥验证
+		User:            username,        // 连接用户名
+		Auth:            auth,            // 认证方式列表
+		Timeout:         timeout,         // 连接超时时间
+		HostKeyCallback: hostKeyCallback, // 主机公钥校验回调
 	}
 
 	// 通过TCP协议连接远程主机
-	client, err := ssh.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)), sshConfig)
+	client, err := ssh.Dial("tcp", net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port)), sshConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -41,87 +86,75 @@ func NewSSHClient(host string, port int, user string, password string) (*SSHClie
 	return &SSHClient{client: client, config: sshConfig}, nil
 }
 
-// Close 关闭SSHClient连接
+// Close 关闭SSHClient连接，同时关闭已缓存的SFTP客户端
 func (c *SSHClient) Close() error {
+	c.sftpMu.Lock()
+	if c.sftpClient != nil {
+		c.sftpClient.Close()
+		c.sftpClient = nil
+	}
+	c.sftpMu.Unlock()
+
 	return c.client.Close()
 }
 
-// RunCommand 执行远程命令
-func (c *SSHClient) RunCommand(command string) (string, error) {
-	session, err := c.client.NewSession() // 创建新的SSH会话
-	if err != nil {
-		return "", err
+// SFTP 返回底层的SFTP客户端，首次调用时才会建立连接，之后会被缓存复用；
+// 包内的Upload/Download等操作通过withSFTP发现连接已断开时会丢弃缓存，下一次调用会重新打开一个新的SFTP客户端；
+// SFTP本身不检测连接状态，调用方直接使用返回的客户端出错时，缓存不会被丢弃。
+// 调用方可以用它执行Stat、Remove、Rename、Mkdir、Chmod等Upload/Download未覆盖的操作。
+func (c *SSHClient) SFTP() (*sftp.Client, error) {
+	c.sftpMu.Lock()
+	defer c.sftpMu.Unlock()
+
+	if c.sftpClient != nil {
+		return c.sftpClient, nil
 	}
-	defer session.Close() // 确保会话结束后关闭
 
-	output, err := session.Output(command) // 执行命令并获取输出结果
+	sftpClient, err := sftp.NewClient(c.client)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(output), nil
+	c.sftpClient = sftpClient
+	return c.sftpClient, nil
 }
 
-// CopyFile 传输本地文件到远程主机
-func (c *SSHClient) CopyFile(localPath string, remotePath string) error {
-	src, err := os.Open(localPath) // 打开本地文件
-	if err != nil {
-		return err
-	}
-	defer src.Close()
-
-	dst, err := c.client.Create(remotePath) // 在远程主机上创建文件
-	if err != nil {
-		return err
+// resetSFTP 丢弃已缓存的SFTP客户端，下一次SFTP()调用会重新建立连接
+func (c *SSHClient) resetSFTP() {
+	c.sftpMu.Lock()
+	if c.sftpClient != nil {
+		c.sftpClient.Close()
+		c.sftpClient = nil
 	}
-	defer dst.Close()
+	c.sftpMu.Unlock()
+}
 
-	_, err = io.Copy(dst, src) // 将本地文件内容拷贝到远程文件中
+// withSFTP获取当前缓存的SFTP客户端并执行fn；如果fn的错误表明底层连接已经断开，
+// 会丢弃这个客户端、重新打开一个新的后重试一次，而不是把同一个失效的客户端一直用下去。
+func (c *SSHClient) withSFTP(fn func(*sftp.Client) error) error {
+	sftpClient, err := c.SFTP()
 	if err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// CopyDir 将本地目录复制到远程服务器
-func (c *SSHClient) CopyDir(localPath string, remotePath string) error {
-	// 遍历本地目录树
-	err := filepath.Walk(localPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+	err = fn(sftpClient)
+	if err != nil && isDeadConnErr(err) {
+		c.resetSFTP()
 
-		// 获取目标路径
-		relPath, err := filepath.Rel(localPath, path)
+		sftpClient, err = c.SFTP()
 		if err != nil {
 			return err
 		}
-		dstPath := filepath.Join(remotePath, relPath)
-
-		if info.IsDir() {
-			// 如果远端目录不存在，请创建远端目录
-			err = c.RunCommand(fmt.Sprintf("mkdir -p '%s'", dstPath))
-			if err != nil {
-				return err
-			}
-		} else {
-			// 将文件复制到远程服务器
-			wg := &sync.WaitGroup{}
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				err = c.CopyFile(path, dstPath)
-			}()
-			wg.Wait()
-			if err != nil {
-				return err
-			}
-		}
-
-		return nil
-	})
+		err = fn(sftpClient)
+	}
 
 	return err
 }
 
+// isDeadConnErr判断err是否表明底层SFTP连接已经断开（而不是一次普通的操作失败，比如文件不存在）
+func isDeadConnErr(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, os.ErrClosed) ||
+		errors.Is(err, sftp.ErrSSHFxConnectionLost)
+}
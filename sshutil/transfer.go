@@ -0,0 +1,110 @@
+package sshutil
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/sftp"
+)
+
+// Upload 将本地的localPath上传到远程主机的remotePath。
+// 目标路径的解析遵循rsync风格的尾部斜杠规则（见resolveUploadRoot/resolveFileDest）：
+// localPath是目录时会被当成UploadDir处理；localPath是文件且remotePath以"/"结尾时，
+// 文件名会被追加到remotePath后，否则remotePath被当作目标文件名。
+func (c *SSHClient) Upload(localPath, remotePath string) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		_, err := c.UploadDir(localPath, remotePath)
+		return err
+	}
+
+	dst := resolveFileDest(filepath.Base(filepath.Clean(localPath)), remotePath, true)
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		_, err := uploadFile(sftpClient, localPath, dst, info, nil)
+		return err
+	})
+}
+
+// Download 将远程主机上的remotePath下载到本地的localPath，尾部斜杠规则与Upload对称。
+func (c *SSHClient) Download(remotePath, localPath string) error {
+	var info os.FileInfo
+	err := c.withSFTP(func(sftpClient *sftp.Client) error {
+		var statErr error
+		info, statErr = sftpClient.Stat(remotePath)
+		return statErr
+	})
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		_, err := c.DownloadDir(remotePath, localPath)
+		return err
+	}
+
+	dst := resolveFileDest(path.Base(path.Clean(filepath.ToSlash(remotePath))), localPath, false)
+	return c.withSFTP(func(sftpClient *sftp.Client) error {
+		_, err := downloadFile(sftpClient, remotePath, dst, info, nil)
+		return err
+	})
+}
+
+// uploadFile 拷贝单个本地文件到远程主机，并在拷贝完成后同步mtime和权限。
+// progress非nil时会在每次底层Write后回调一次传输进度。
+func uploadFile(sftpClient *sftp.Client, localPath, remotePath string, info os.FileInfo, progress ProgressFunc) (int64, error) {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dst, err := sftpClient.Create(remotePath)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(trackWriter(remotePath, dst, info.Size(), progress), src)
+	if err != nil {
+		return written, err
+	}
+
+	if err := sftpClient.Chmod(remotePath, info.Mode().Perm()); err != nil {
+		return written, err
+	}
+
+	return written, sftpClient.Chtimes(remotePath, info.ModTime(), info.ModTime())
+}
+
+// downloadFile 拷贝远程主机上的单个文件到本地，并在拷贝完成后同步mtime和权限。
+// progress非nil时会在每次底层Write后回调一次传输进度。
+func downloadFile(sftpClient *sftp.Client, remotePath, localPath string, info os.FileInfo, progress ProgressFunc) (int64, error) {
+	src, err := sftpClient.Open(remotePath)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(trackWriter(remotePath, dst, info.Size(), progress), src)
+	if err != nil {
+		return written, err
+	}
+
+	if err := dst.Chmod(info.Mode().Perm()); err != nil {
+		return written, err
+	}
+
+	return written, os.Chtimes(localPath, info.ModTime(), info.ModTime())
+}